@@ -0,0 +1,44 @@
+package transmission
+
+import "context"
+
+// TorrentClient is the set of torrent operations common to every backend
+// this package supports. Client (Transmission) and QBittorrentClient
+// (qBittorrent) both implement it so callers can depend on the interface
+// instead of a specific daemon.
+type TorrentClient interface {
+	// GetTorrents returns the current torrent list.
+	GetTorrents() (*[]Torrent, error)
+	// GetTorrentsCtx is GetTorrents with a caller-supplied context.
+	GetTorrentsCtx(ctx context.Context) (*[]Torrent, error)
+	// AddTorrent adds a torrent from a filename/URL or base64-encoded
+	// metainfo. Implementations return the newly added Torrent whenever
+	// they can resolve it; a backend that has no way to identify which
+	// torrent it just added (e.g. QBittorrentClient, when filename is a
+	// plain non-magnet URL it can't resolve to an infohash up front) may
+	// return a nil *Torrent alongside a nil error, so callers must
+	// nil-check the result rather than assuming success implies one, as
+	// Client (Transmission) always returns.
+	AddTorrent(filename, metadata string) (*Torrent, error)
+	// RemoveTorrents removes torrents, optionally deleting their local data.
+	RemoveTorrents(torrents []*Torrent, removeData bool) error
+
+	// Start starts the given torrents.
+	Start(torrents []*Torrent) error
+	// StartNow starts the given torrents, bypassing the download queue.
+	StartNow(torrents []*Torrent) error
+	// Stop stops the given torrents.
+	Stop(torrents []*Torrent) error
+	// Verify re-checks the given torrents against local data.
+	Verify(torrents []*Torrent) error
+	// Reannounce asks trackers for more peers for the given torrents.
+	Reannounce(torrents []*Torrent) error
+
+	// SetLocation moves the given torrents' data to a new location.
+	SetLocation(torrents []*Torrent, location string, move bool) error
+}
+
+var (
+	_ TorrentClient = (*Client)(nil)
+	_ TorrentClient = (*QBittorrentClient)(nil)
+)
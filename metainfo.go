@@ -0,0 +1,336 @@
+package transmission
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MetaInfoFile describes a single file within a torrent.
+type MetaInfoFile struct {
+	Path   string
+	Length int64
+}
+
+// MetaInfo is the subset of a .torrent file's metadata (or a magnet link's
+// parameters) this package understands, resolved before the torrent is
+// ever handed to a backend. It lets callers dedupe by InfoHash before
+// uploading.
+type MetaInfo struct {
+	InfoHash    string
+	Name        string
+	Files       []MetaInfoFile
+	PieceLength int64
+	TotalSize   int64
+	Trackers    []string
+}
+
+// ParseMetaInfoFile reads and decodes a .torrent file from path, returning
+// its MetaInfo alongside the raw bytes (ready to be base64-encoded into an
+// addTorrentArg.Metainfo).
+func ParseMetaInfoFile(path string) (*MetaInfo, []byte, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	mi, err := parseMetaInfoBytes(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("transmission: decoding %s: %w", path, err)
+	}
+	return mi, raw, nil
+}
+
+// parseMetaInfoBytes decodes an in-memory .torrent file, as opposed to
+// ParseMetaInfoFile which reads one from disk. It's also used to recover a
+// torrent's infohash from the raw metainfo backends don't hand back
+// themselves (see QBittorrentClient.AddTorrentCtx).
+func parseMetaInfoBytes(raw []byte) (*MetaInfo, error) {
+	decoded, _, err := bdecode(raw, 0)
+	if err != nil {
+		return nil, err
+	}
+	top, ok := decoded.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("not a bencoded dictionary")
+	}
+	info, ok := top["info"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("has no info dictionary")
+	}
+
+	infoBytes, err := bencode(info)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha1.Sum(infoBytes)
+
+	mi := &MetaInfo{
+		InfoHash: fmt.Sprintf("%x", hash),
+		Name:     stringField(info, "name"),
+	}
+	if pl, ok := info["piece length"].(int64); ok {
+		mi.PieceLength = pl
+	}
+
+	if length, ok := info["length"].(int64); ok {
+		mi.Files = []MetaInfoFile{{Path: mi.Name, Length: length}}
+		mi.TotalSize = length
+	} else if files, ok := info["files"].([]interface{}); ok {
+		for _, f := range files {
+			fm, ok := f.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			length, _ := fm["length"].(int64)
+			var parts []string
+			if pathList, ok := fm["path"].([]interface{}); ok {
+				for _, p := range pathList {
+					if s, ok := p.(string); ok {
+						parts = append(parts, s)
+					}
+				}
+			}
+			mi.Files = append(mi.Files, MetaInfoFile{
+				Path:   strings.Join(append([]string{mi.Name}, parts...), "/"),
+				Length: length,
+			})
+			mi.TotalSize += length
+		}
+	}
+
+	if announce := stringField(top, "announce"); announce != "" {
+		mi.Trackers = append(mi.Trackers, announce)
+	}
+	if announceList, ok := top["announce-list"].([]interface{}); ok {
+		for _, tier := range announceList {
+			tierList, ok := tier.([]interface{})
+			if !ok {
+				continue
+			}
+			for _, t := range tierList {
+				if s, ok := t.(string); ok {
+					mi.Trackers = append(mi.Trackers, s)
+				}
+			}
+		}
+	}
+
+	return mi, nil
+}
+
+// ParseMagnet decodes a "magnet:?xt=urn:btih:..." URI into a MetaInfo.
+// Magnets carry no file listing or piece length, only the infohash, a
+// display name, and trackers.
+func ParseMagnet(uri string) (*MetaInfo, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "magnet" {
+		return nil, fmt.Errorf("transmission: %q is not a magnet URI", uri)
+	}
+	q := u.Query()
+
+	var hash string
+	for _, xt := range q["xt"] {
+		const prefix = "urn:btih:"
+		if strings.HasPrefix(xt, prefix) {
+			hash = strings.ToLower(strings.TrimPrefix(xt, prefix))
+			break
+		}
+	}
+	if hash == "" {
+		return nil, errors.New("transmission: magnet URI has no urn:btih infohash")
+	}
+
+	return &MetaInfo{
+		InfoHash: hash,
+		Name:     q.Get("dn"),
+		Trackers: q["tr"],
+	}, nil
+}
+
+func stringField(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// AddTorrentFile reads and parses the .torrent file at path and adds it,
+// base64-encoding its contents into the torrent-add metainfo argument.
+func (c *Client) AddTorrentFile(path string) (*Torrent, error) {
+	return c.AddTorrentFileCtx(context.Background(), path)
+}
+
+// AddTorrentFileCtx is AddTorrentFile with a caller-supplied context.
+func (c *Client) AddTorrentFileCtx(ctx context.Context, path string) (*Torrent, error) {
+	_, raw, err := ParseMetaInfoFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.AddTorrentCtx(ctx, "", base64.StdEncoding.EncodeToString(raw))
+}
+
+// AddMagnet adds a torrent from a magnet URI. Transmission accepts magnet
+// URIs directly as the torrent-add filename argument, so the URI is passed
+// through unchanged; ParseMagnet is used only to validate it. Callers that
+// want the infohash before adding, e.g. to dedupe, call ParseMagnet
+// themselves.
+func (c *Client) AddMagnet(uri string) (*Torrent, error) {
+	return c.AddMagnetCtx(context.Background(), uri)
+}
+
+// AddMagnetCtx is AddMagnet with a caller-supplied context.
+func (c *Client) AddMagnetCtx(ctx context.Context, uri string) (*Torrent, error) {
+	if _, err := ParseMagnet(uri); err != nil {
+		return nil, err
+	}
+	return c.AddTorrentCtx(ctx, uri, "")
+}
+
+// bdecode decodes a single bencoded value starting at offset i, returning
+// the value, the offset just past it, and an error if the data is malformed.
+// Dictionaries decode to map[string]interface{}, lists to []interface{},
+// byte strings to string, and integers to int64.
+func bdecode(data []byte, i int) (interface{}, int, error) {
+	if i >= len(data) {
+		return nil, i, errors.New("bencode: unexpected end of data")
+	}
+	switch {
+	case data[i] == 'i':
+		end := indexByte(data, 'e', i+1)
+		if end < 0 {
+			return nil, i, errors.New("bencode: unterminated integer")
+		}
+		n, err := strconv.ParseInt(string(data[i+1:end]), 10, 64)
+		if err != nil {
+			return nil, i, err
+		}
+		return n, end + 1, nil
+
+	case data[i] == 'l':
+		i++
+		var list []interface{}
+		for i < len(data) && data[i] != 'e' {
+			var v interface{}
+			var err error
+			v, i, err = bdecode(data, i)
+			if err != nil {
+				return nil, i, err
+			}
+			list = append(list, v)
+		}
+		if i >= len(data) {
+			return nil, i, errors.New("bencode: unterminated list")
+		}
+		return list, i + 1, nil
+
+	case data[i] == 'd':
+		i++
+		m := map[string]interface{}{}
+		for i < len(data) && data[i] != 'e' {
+			var key interface{}
+			var err error
+			key, i, err = bdecode(data, i)
+			if err != nil {
+				return nil, i, err
+			}
+			keyStr, ok := key.(string)
+			if !ok {
+				return nil, i, errors.New("bencode: dictionary key is not a string")
+			}
+			var val interface{}
+			val, i, err = bdecode(data, i)
+			if err != nil {
+				return nil, i, err
+			}
+			m[keyStr] = val
+		}
+		if i >= len(data) {
+			return nil, i, errors.New("bencode: unterminated dictionary")
+		}
+		return m, i + 1, nil
+
+	case data[i] >= '0' && data[i] <= '9':
+		colon := indexByte(data, ':', i)
+		if colon < 0 {
+			return nil, i, errors.New("bencode: malformed byte string length")
+		}
+		n, err := strconv.Atoi(string(data[i:colon]))
+		if err != nil {
+			return nil, i, err
+		}
+		if n < 0 {
+			return nil, i, errors.New("bencode: negative byte string length")
+		}
+		start := colon + 1
+		end := start + n
+		if end < start || end > len(data) {
+			return nil, i, errors.New("bencode: byte string runs past end of data")
+		}
+		return string(data[start:end]), end, nil
+
+	default:
+		return nil, i, fmt.Errorf("bencode: unexpected token %q", data[i])
+	}
+}
+
+func indexByte(data []byte, b byte, from int) int {
+	for i := from; i < len(data); i++ {
+		if data[i] == b {
+			return i
+		}
+	}
+	return -1
+}
+
+// bencode re-encodes a decoded value, used to recompute the SHA1 infohash
+// of a torrent's info dictionary. Dictionary keys are sorted, matching the
+// canonical bencode form torrent files are expected to use.
+func bencode(v interface{}) ([]byte, error) {
+	var b strings.Builder
+	if err := bencodeTo(&b, v); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+func bencodeTo(b *strings.Builder, v interface{}) error {
+	switch val := v.(type) {
+	case string:
+		fmt.Fprintf(b, "%d:%s", len(val), val)
+	case int64:
+		fmt.Fprintf(b, "i%de", val)
+	case []interface{}:
+		b.WriteByte('l')
+		for _, item := range val {
+			if err := bencodeTo(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('e')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteByte('d')
+		for _, k := range keys {
+			fmt.Fprintf(b, "%d:%s", len(k), k)
+			if err := bencodeTo(b, val[k]); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('e')
+	default:
+		return fmt.Errorf("bencode: unsupported type %T", v)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package transmission
+
+import "testing"
+
+func TestBdecodeString(t *testing.T) {
+	v, n, err := bdecode([]byte("4:spam"), 0)
+	if err != nil {
+		t.Fatalf("bdecode: %v", err)
+	}
+	if v != "spam" || n != 6 {
+		t.Fatalf("got (%v, %d), want (spam, 6)", v, n)
+	}
+}
+
+func TestBdecodeDict(t *testing.T) {
+	v, _, err := bdecode([]byte("d3:bar4:spam3:fooi42ee"), 0)
+	if err != nil {
+		t.Fatalf("bdecode: %v", err)
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		t.Fatalf("got %T, want map[string]interface{}", v)
+	}
+	if m["bar"] != "spam" || m["foo"] != int64(42) {
+		t.Fatalf("unexpected dict contents: %v", m)
+	}
+}
+
+func TestBdecodeRejectsMalformedLength(t *testing.T) {
+	cases := []string{
+		"-5:hello",                           // not a valid bencode token at all
+		"99999999999999999999999999999999:x", // length overflows a 64-bit int
+	}
+	for _, c := range cases {
+		if _, _, err := bdecode([]byte(c), 0); err == nil {
+			t.Errorf("bdecode(%q): expected error, got none", c)
+		}
+	}
+}
+
+func TestBdecodeRejectsOverflowingLength(t *testing.T) {
+	// A length near MaxInt64 makes start+n wrap around to a value less
+	// than start; this must be rejected rather than panic on the slice.
+	input := "9223372036854775807:x"
+	if _, _, err := bdecode([]byte(input), 0); err == nil {
+		t.Error("bdecode: expected error for overflowing length, got none")
+	}
+}
+
+func TestParseMagnet(t *testing.T) {
+	uri := "magnet:?xt=urn:btih:AABBCCDDEEFF00112233445566778899AABBCCDD&dn=Example&tr=http://tracker.example/announce"
+	mi, err := ParseMagnet(uri)
+	if err != nil {
+		t.Fatalf("ParseMagnet: %v", err)
+	}
+	if mi.InfoHash != "aabbccddeeff00112233445566778899aabbccdd" {
+		t.Errorf("InfoHash = %q", mi.InfoHash)
+	}
+	if mi.Name != "Example" {
+		t.Errorf("Name = %q", mi.Name)
+	}
+	if len(mi.Trackers) != 1 || mi.Trackers[0] != "http://tracker.example/announce" {
+		t.Errorf("Trackers = %v", mi.Trackers)
+	}
+}
+
+func TestParseMagnetRejectsNonMagnet(t *testing.T) {
+	if _, err := ParseMagnet("http://example.com"); err == nil {
+		t.Error("ParseMagnet: expected error for non-magnet URI")
+	}
+}
+
+func TestParseMagnetRequiresInfoHash(t *testing.T) {
+	if _, err := ParseMagnet("magnet:?dn=Example"); err == nil {
+		t.Error("ParseMagnet: expected error when urn:btih is missing")
+	}
+}
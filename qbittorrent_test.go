@@ -0,0 +1,125 @@
+package transmission
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestQBittorrentStableIDsAcrossReorder is a regression test: ids must be
+// keyed off torrent hash, not response position, so a reordering or
+// insertion in /api/v2/torrents/info between polls doesn't relabel an
+// unchanged torrent under a different id.
+func TestQBittorrentStableIDsAcrossReorder(t *testing.T) {
+	responses := []string{
+		`[{"hash":"aaa","name":"A"},{"hash":"bbb","name":"B"}]`,
+		// "bbb" now sorts ahead of "aaa", and a new torrent "ccc" was
+		// inserted at the front.
+		`[{"hash":"ccc","name":"C"},{"hash":"bbb","name":"B"},{"hash":"aaa","name":"A"}]`,
+	}
+	call := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/auth/login" {
+			w.Write([]byte("Ok."))
+			return
+		}
+		w.Write([]byte(responses[call]))
+		if call < len(responses)-1 {
+			call++
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewQBittorrent(QBittorrentConfig{Address: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := c.GetTorrentsCtx(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	idByHash := map[string]int{}
+	for _, tt := range *first {
+		idByHash[tt.HashString] = tt.ID
+	}
+
+	second, err := c.GetTorrentsCtx(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range *second {
+		if tt.HashString == "ccc" {
+			continue
+		}
+		if idByHash[tt.HashString] != tt.ID {
+			t.Errorf("hash %q: id changed from %d to %d across polls", tt.HashString, idByHash[tt.HashString], tt.ID)
+		}
+	}
+}
+
+// TestQBittorrentAddTorrentResolvesMagnet is a regression test: adding a
+// magnet link must return the new Torrent, not (nil, nil), once it shows
+// up in /api/v2/torrents/info.
+func TestQBittorrentAddTorrentResolvesMagnet(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/auth/login":
+			w.Write([]byte("Ok."))
+		case "/api/v2/torrents/add":
+			w.Write([]byte("Ok."))
+		case "/api/v2/torrents/info":
+			fmt.Fprintf(w, `[{"hash":%q,"name":"T"}]`, hash)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewQBittorrent(QBittorrentConfig{Address: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.AddTorrentCtx(context.Background(), "magnet:?xt=urn:btih:"+hash, "")
+	if err != nil {
+		t.Fatalf("AddTorrentCtx: %v", err)
+	}
+	if got == nil {
+		t.Fatal("AddTorrentCtx returned a nil Torrent for a resolvable magnet add")
+	}
+	if got.HashString != hash {
+		t.Errorf("HashString = %q, want %q", got.HashString, hash)
+	}
+}
+
+// TestQBittorrentAddTorrentURLFallsBackToNil documents that a plain,
+// non-magnet URL add can't be resolved to an infohash up front, so
+// AddTorrentCtx falls back to (nil, nil) on success rather than erroring.
+func TestQBittorrentAddTorrentURLFallsBackToNil(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/auth/login":
+			w.Write([]byte("Ok."))
+		case "/api/v2/torrents/add":
+			w.Write([]byte("Ok."))
+		case "/api/v2/torrents/info":
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewQBittorrent(QBittorrentConfig{Address: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.AddTorrentCtx(context.Background(), "https://example.com/file.torrent", "")
+	if err != nil {
+		t.Fatalf("AddTorrentCtx: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("AddTorrentCtx = %+v, want nil for an unresolvable URL add", got)
+	}
+}
@@ -0,0 +1,473 @@
+package transmission
+
+import "context"
+
+// Selector identifies the torrents an RPC call applies to. Transmission
+// accepts either numeric ids or SHA1 hash strings in the same "ids" array,
+// so Selector is built from whichever one the caller has on hand.
+type Selector []interface{}
+
+// IDs builds a Selector from torrent ids.
+func IDs(ids ...int) Selector {
+	s := make(Selector, len(ids))
+	for i, id := range ids {
+		s[i] = id
+	}
+	return s
+}
+
+// Hashes builds a Selector from torrent SHA1 hash strings.
+func Hashes(hashes ...string) Selector {
+	s := make(Selector, len(hashes))
+	for i, h := range hashes {
+		s[i] = h
+	}
+	return s
+}
+
+// selectorOf builds a Selector from a slice of torrents, using their ids.
+func selectorOf(torrents []*Torrent) Selector {
+	s := make(Selector, len(torrents))
+	for i, t := range torrents {
+		s[i] = t.ID
+	}
+	return s
+}
+
+type torrentActionArg struct {
+	Ids Selector `json:"ids"`
+}
+
+// torrentAction applies method to ids. Transmission treats a request with
+// no "ids" field as "apply to every torrent", so an empty Selector is
+// short-circuited into a no-op rather than ever being sent that way.
+func (c *Client) torrentAction(ctx context.Context, method string, ids Selector) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	tReq := &Request{
+		Arguments: torrentActionArg{Ids: ids},
+		Method:    method,
+	}
+	r := &Response{}
+	return c.request(ctx, tReq, r)
+}
+
+// Start starts the given torrents.
+func (c *Client) Start(torrents []*Torrent) error {
+	return c.StartCtx(context.Background(), torrents)
+}
+
+// StartCtx is Start with a caller-supplied context.
+func (c *Client) StartCtx(ctx context.Context, torrents []*Torrent) error {
+	return c.torrentAction(ctx, "torrent-start", selectorOf(torrents))
+}
+
+// StartNow starts the given torrents, bypassing the download queue.
+func (c *Client) StartNow(torrents []*Torrent) error {
+	return c.StartNowCtx(context.Background(), torrents)
+}
+
+// StartNowCtx is StartNow with a caller-supplied context.
+func (c *Client) StartNowCtx(ctx context.Context, torrents []*Torrent) error {
+	return c.torrentAction(ctx, "torrent-start-now", selectorOf(torrents))
+}
+
+// Stop stops the given torrents.
+func (c *Client) Stop(torrents []*Torrent) error {
+	return c.StopCtx(context.Background(), torrents)
+}
+
+// StopCtx is Stop with a caller-supplied context.
+func (c *Client) StopCtx(ctx context.Context, torrents []*Torrent) error {
+	return c.torrentAction(ctx, "torrent-stop", selectorOf(torrents))
+}
+
+// Verify asks transmission to re-check the given torrents against local data.
+func (c *Client) Verify(torrents []*Torrent) error {
+	return c.VerifyCtx(context.Background(), torrents)
+}
+
+// VerifyCtx is Verify with a caller-supplied context.
+func (c *Client) VerifyCtx(ctx context.Context, torrents []*Torrent) error {
+	return c.torrentAction(ctx, "torrent-verify", selectorOf(torrents))
+}
+
+// Reannounce asks trackers for more peers for the given torrents.
+func (c *Client) Reannounce(torrents []*Torrent) error {
+	return c.ReannounceCtx(context.Background(), torrents)
+}
+
+// ReannounceCtx is Reannounce with a caller-supplied context.
+func (c *Client) ReannounceCtx(ctx context.Context, torrents []*Torrent) error {
+	return c.torrentAction(ctx, "torrent-reannounce", selectorOf(torrents))
+}
+
+// MoveQueueTop moves the given torrents to the top of the download queue.
+func (c *Client) MoveQueueTop(torrents []*Torrent) error {
+	return c.MoveQueueTopCtx(context.Background(), torrents)
+}
+
+// MoveQueueTopCtx is MoveQueueTop with a caller-supplied context.
+func (c *Client) MoveQueueTopCtx(ctx context.Context, torrents []*Torrent) error {
+	return c.torrentAction(ctx, "queue-move-top", selectorOf(torrents))
+}
+
+// MoveQueueUp moves the given torrents up one position in the download queue.
+func (c *Client) MoveQueueUp(torrents []*Torrent) error {
+	return c.MoveQueueUpCtx(context.Background(), torrents)
+}
+
+// MoveQueueUpCtx is MoveQueueUp with a caller-supplied context.
+func (c *Client) MoveQueueUpCtx(ctx context.Context, torrents []*Torrent) error {
+	return c.torrentAction(ctx, "queue-move-up", selectorOf(torrents))
+}
+
+// MoveQueueDown moves the given torrents down one position in the download queue.
+func (c *Client) MoveQueueDown(torrents []*Torrent) error {
+	return c.MoveQueueDownCtx(context.Background(), torrents)
+}
+
+// MoveQueueDownCtx is MoveQueueDown with a caller-supplied context.
+func (c *Client) MoveQueueDownCtx(ctx context.Context, torrents []*Torrent) error {
+	return c.torrentAction(ctx, "queue-move-down", selectorOf(torrents))
+}
+
+// MoveQueueBottom moves the given torrents to the bottom of the download queue.
+func (c *Client) MoveQueueBottom(torrents []*Torrent) error {
+	return c.MoveQueueBottomCtx(context.Background(), torrents)
+}
+
+// MoveQueueBottomCtx is MoveQueueBottom with a caller-supplied context.
+func (c *Client) MoveQueueBottomCtx(ctx context.Context, torrents []*Torrent) error {
+	return c.torrentAction(ctx, "queue-move-bottom", selectorOf(torrents))
+}
+
+type setTorrentArg struct {
+	Ids               Selector `json:"ids"`
+	Labels            []string `json:"labels,omitempty"`
+	BandwidthPriority *int     `json:"bandwidthPriority,omitempty"`
+	DownloadLimit     *int     `json:"downloadLimit,omitempty"`
+	DownloadLimited   *bool    `json:"downloadLimited,omitempty"`
+	UploadLimit       *int     `json:"uploadLimit,omitempty"`
+	UploadLimited     *bool    `json:"uploadLimited,omitempty"`
+	FilesWanted       []int    `json:"files-wanted,omitempty"`
+	FilesUnwanted     []int    `json:"files-unwanted,omitempty"`
+	PriorityHigh      []int    `json:"priority-high,omitempty"`
+	PriorityLow       []int    `json:"priority-low,omitempty"`
+	PriorityNormal    []int    `json:"priority-normal,omitempty"`
+	TrackerAdd        []string `json:"trackerAdd,omitempty"`
+	TrackerRemove     []int    `json:"trackerRemove,omitempty"`
+}
+
+// setTorrent applies arg via torrent-set. Like torrentAction, an empty
+// Selector is a no-op: Transmission treats a missing "ids" as "every
+// torrent", which must never be what an empty selector means here.
+func (c *Client) setTorrent(ctx context.Context, arg setTorrentArg) error {
+	if len(arg.Ids) == 0 {
+		return nil
+	}
+	tReq := &Request{
+		Arguments: arg,
+		Method:    "torrent-set",
+	}
+	r := &Response{}
+	return c.request(ctx, tReq, r)
+}
+
+// SetLabels replaces the label set on the given torrents.
+func (c *Client) SetLabels(torrents []*Torrent, labels []string) error {
+	return c.SetLabelsCtx(context.Background(), torrents, labels)
+}
+
+// SetLabelsCtx is SetLabels with a caller-supplied context.
+func (c *Client) SetLabelsCtx(ctx context.Context, torrents []*Torrent, labels []string) error {
+	return c.setTorrent(ctx, setTorrentArg{Ids: selectorOf(torrents), Labels: labels})
+}
+
+// SetBandwidthPriority sets the bandwidth priority (-1, 0 or 1) of the given torrents.
+func (c *Client) SetBandwidthPriority(torrents []*Torrent, priority int) error {
+	return c.SetBandwidthPriorityCtx(context.Background(), torrents, priority)
+}
+
+// SetBandwidthPriorityCtx is SetBandwidthPriority with a caller-supplied context.
+func (c *Client) SetBandwidthPriorityCtx(ctx context.Context, torrents []*Torrent, priority int) error {
+	return c.setTorrent(ctx, setTorrentArg{Ids: selectorOf(torrents), BandwidthPriority: &priority})
+}
+
+// SetBandwidthLimits sets per-torrent upload/download speed limits, in KB/s.
+// A nil limit leaves that direction unchanged.
+func (c *Client) SetBandwidthLimits(torrents []*Torrent, downloadLimit, uploadLimit *int) error {
+	return c.SetBandwidthLimitsCtx(context.Background(), torrents, downloadLimit, uploadLimit)
+}
+
+// SetBandwidthLimitsCtx is SetBandwidthLimits with a caller-supplied context.
+func (c *Client) SetBandwidthLimitsCtx(ctx context.Context, torrents []*Torrent, downloadLimit, uploadLimit *int) error {
+	arg := setTorrentArg{Ids: selectorOf(torrents)}
+	if downloadLimit != nil {
+		arg.DownloadLimit = downloadLimit
+		limited := true
+		arg.DownloadLimited = &limited
+	}
+	if uploadLimit != nil {
+		arg.UploadLimit = uploadLimit
+		limited := true
+		arg.UploadLimited = &limited
+	}
+	return c.setTorrent(ctx, arg)
+}
+
+// SetFilesWanted marks the given file indices as wanted or unwanted on a single torrent.
+func (c *Client) SetFilesWanted(torrent *Torrent, wanted, unwanted []int) error {
+	return c.SetFilesWantedCtx(context.Background(), torrent, wanted, unwanted)
+}
+
+// SetFilesWantedCtx is SetFilesWanted with a caller-supplied context.
+func (c *Client) SetFilesWantedCtx(ctx context.Context, torrent *Torrent, wanted, unwanted []int) error {
+	return c.setTorrent(ctx, setTorrentArg{
+		Ids:           selectorOf([]*Torrent{torrent}),
+		FilesWanted:   wanted,
+		FilesUnwanted: unwanted,
+	})
+}
+
+// SetFilePriorities sets file download priorities on a single torrent by file index.
+func (c *Client) SetFilePriorities(torrent *Torrent, high, low, normal []int) error {
+	return c.SetFilePrioritiesCtx(context.Background(), torrent, high, low, normal)
+}
+
+// SetFilePrioritiesCtx is SetFilePriorities with a caller-supplied context.
+func (c *Client) SetFilePrioritiesCtx(ctx context.Context, torrent *Torrent, high, low, normal []int) error {
+	return c.setTorrent(ctx, setTorrentArg{
+		Ids:            selectorOf([]*Torrent{torrent}),
+		PriorityHigh:   high,
+		PriorityLow:    low,
+		PriorityNormal: normal,
+	})
+}
+
+// AddTrackers adds tracker announce URLs to a single torrent.
+func (c *Client) AddTrackers(torrent *Torrent, announceURLs []string) error {
+	return c.AddTrackersCtx(context.Background(), torrent, announceURLs)
+}
+
+// AddTrackersCtx is AddTrackers with a caller-supplied context.
+func (c *Client) AddTrackersCtx(ctx context.Context, torrent *Torrent, announceURLs []string) error {
+	return c.setTorrent(ctx, setTorrentArg{Ids: selectorOf([]*Torrent{torrent}), TrackerAdd: announceURLs})
+}
+
+// RemoveTrackers removes trackers from a single torrent by tracker id.
+func (c *Client) RemoveTrackers(torrent *Torrent, trackerIds []int) error {
+	return c.RemoveTrackersCtx(context.Background(), torrent, trackerIds)
+}
+
+// RemoveTrackersCtx is RemoveTrackers with a caller-supplied context.
+func (c *Client) RemoveTrackersCtx(ctx context.Context, torrent *Torrent, trackerIds []int) error {
+	return c.setTorrent(ctx, setTorrentArg{Ids: selectorOf([]*Torrent{torrent}), TrackerRemove: trackerIds})
+}
+
+type setLocationArg struct {
+	Ids      Selector `json:"ids"`
+	Move     bool     `json:"move"`
+	Location string   `json:"location"`
+}
+
+// SetLocation moves the given torrents' data to a new location. If move is
+// true the existing data is moved on disk; otherwise transmission just
+// starts looking for data at the new location.
+func (c *Client) SetLocation(torrents []*Torrent, location string, move bool) error {
+	return c.SetLocationCtx(context.Background(), torrents, location, move)
+}
+
+// SetLocationCtx is SetLocation with a caller-supplied context. An empty
+// torrents is a no-op: Transmission treats a missing "ids" as "every
+// torrent", which must never be what zero torrents means here.
+func (c *Client) SetLocationCtx(ctx context.Context, torrents []*Torrent, location string, move bool) error {
+	if len(torrents) == 0 {
+		return nil
+	}
+	tReq := &Request{
+		Arguments: setLocationArg{
+			Ids:      selectorOf(torrents),
+			Location: location,
+			Move:     move,
+		},
+		Method: "torrent-set-location",
+	}
+	r := &Response{}
+	return c.request(ctx, tReq, r)
+}
+
+type renamePathArg struct {
+	Ids  Selector `json:"ids,omitempty"`
+	Path string   `json:"path"`
+	Name string   `json:"name"`
+}
+
+type renamePathResult struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+}
+
+// RenamePath renames a file or directory within a single torrent.
+func (c *Client) RenamePath(torrent *Torrent, path, name string) error {
+	return c.RenamePathCtx(context.Background(), torrent, path, name)
+}
+
+// RenamePathCtx is RenamePath with a caller-supplied context.
+func (c *Client) RenamePathCtx(ctx context.Context, torrent *Torrent, path, name string) error {
+	tReq := &Request{
+		Arguments: renamePathArg{
+			Ids:  selectorOf([]*Torrent{torrent}),
+			Path: path,
+			Name: name,
+		},
+		Method: "torrent-rename-path",
+	}
+	r := &Response{Arguments: &renamePathResult{}}
+	return c.request(ctx, tReq, r)
+}
+
+// Session holds the subset of session-get/session-set fields this client
+// knows how to read and write.
+type Session struct {
+	DownloadDir           string `json:"download-dir,omitempty"`
+	IncompleteDir         string `json:"incomplete-dir,omitempty"`
+	IncompleteDirEnabled  *bool  `json:"incomplete-dir-enabled,omitempty"`
+	PeerLimitGlobal       int    `json:"peer-limit-global,omitempty"`
+	PeerLimitPerTorrent   int    `json:"peer-limit-per-torrent,omitempty"`
+	SpeedLimitDown        int    `json:"speed-limit-down,omitempty"`
+	SpeedLimitDownEnabled *bool  `json:"speed-limit-down-enabled,omitempty"`
+	SpeedLimitUp          int    `json:"speed-limit-up,omitempty"`
+	SpeedLimitUpEnabled   *bool  `json:"speed-limit-up-enabled,omitempty"`
+	Version               string `json:"version,omitempty"`
+}
+
+// SessionGet returns the current session configuration.
+func (c *Client) SessionGet() (*Session, error) {
+	return c.SessionGetCtx(context.Background())
+}
+
+// SessionGetCtx is SessionGet with a caller-supplied context.
+func (c *Client) SessionGetCtx(ctx context.Context) (*Session, error) {
+	tReq := &Request{Method: "session-get"}
+	r := &Response{Arguments: &Session{}}
+	err := c.request(ctx, tReq, r)
+	if err != nil {
+		return nil, err
+	}
+	return r.Arguments.(*Session), nil
+}
+
+// SessionSet updates the session configuration. Only non-zero fields in
+// session are sent.
+func (c *Client) SessionSet(session Session) error {
+	return c.SessionSetCtx(context.Background(), session)
+}
+
+// SessionSetCtx is SessionSet with a caller-supplied context.
+func (c *Client) SessionSetCtx(ctx context.Context, session Session) error {
+	tReq := &Request{
+		Arguments: session,
+		Method:    "session-set",
+	}
+	r := &Response{}
+	return c.request(ctx, tReq, r)
+}
+
+// SessionStats holds the session-stats response.
+type SessionStats struct {
+	ActiveTorrentCount int `json:"activeTorrentCount"`
+	DownloadSpeed      int `json:"downloadSpeed"`
+	PausedTorrentCount int `json:"pausedTorrentCount"`
+	TorrentCount       int `json:"torrentCount"`
+	UploadSpeed        int `json:"uploadSpeed"`
+}
+
+// SessionStats returns current transfer stats for the session.
+func (c *Client) SessionStats() (*SessionStats, error) {
+	return c.SessionStatsCtx(context.Background())
+}
+
+// SessionStatsCtx is SessionStats with a caller-supplied context.
+func (c *Client) SessionStatsCtx(ctx context.Context) (*SessionStats, error) {
+	tReq := &Request{Method: "session-stats"}
+	r := &Response{Arguments: &SessionStats{}}
+	err := c.request(ctx, tReq, r)
+	if err != nil {
+		return nil, err
+	}
+	return r.Arguments.(*SessionStats), nil
+}
+
+// SessionClose tells transmission to shut down.
+func (c *Client) SessionClose() error {
+	return c.SessionCloseCtx(context.Background())
+}
+
+// SessionCloseCtx is SessionClose with a caller-supplied context.
+func (c *Client) SessionCloseCtx(ctx context.Context) error {
+	tReq := &Request{Method: "session-close"}
+	r := &Response{}
+	return c.request(ctx, tReq, r)
+}
+
+// BlocklistUpdate tells transmission to download the latest blocklist.
+func (c *Client) BlocklistUpdate() error {
+	return c.BlocklistUpdateCtx(context.Background())
+}
+
+// BlocklistUpdateCtx is BlocklistUpdate with a caller-supplied context.
+func (c *Client) BlocklistUpdateCtx(ctx context.Context) error {
+	tReq := &Request{Method: "blocklist-update"}
+	r := &Response{}
+	return c.request(ctx, tReq, r)
+}
+
+type portTestResult struct {
+	PortIsOpen bool `json:"port-is-open"`
+}
+
+// PortTest checks whether transmission's peer port is reachable from outside.
+func (c *Client) PortTest() (bool, error) {
+	return c.PortTestCtx(context.Background())
+}
+
+// PortTestCtx is PortTest with a caller-supplied context.
+func (c *Client) PortTestCtx(ctx context.Context) (bool, error) {
+	tReq := &Request{Method: "port-test"}
+	r := &Response{Arguments: &portTestResult{}}
+	err := c.request(ctx, tReq, r)
+	if err != nil {
+		return false, err
+	}
+	return r.Arguments.(*portTestResult).PortIsOpen, nil
+}
+
+type freeSpaceArg struct {
+	Path string `json:"path"`
+}
+
+type freeSpaceResult struct {
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"size-bytes"`
+}
+
+// FreeSpace returns the free disk space, in bytes, available at path.
+func (c *Client) FreeSpace(path string) (int64, error) {
+	return c.FreeSpaceCtx(context.Background(), path)
+}
+
+// FreeSpaceCtx is FreeSpace with a caller-supplied context.
+func (c *Client) FreeSpaceCtx(ctx context.Context, path string) (int64, error) {
+	tReq := &Request{
+		Arguments: freeSpaceArg{Path: path},
+		Method:    "free-space",
+	}
+	r := &Response{Arguments: &freeSpaceResult{}}
+	err := c.request(ctx, tReq, r)
+	if err != nil {
+		return 0, err
+	}
+	return r.Arguments.(*freeSpaceResult).SizeBytes, nil
+}
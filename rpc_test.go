@@ -0,0 +1,106 @@
+package transmission
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestClient points a Client at srv with no auth and the default retry
+// settings.
+func newTestClient(srv *httptest.Server) *Client {
+	return &Client{httpClient: srv.Client(), conf: &Config{}, endpoint: srv.URL}
+}
+
+func TestStopEmptySelectorIsNoop(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"result":"success"}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv)
+	if err := c.Stop(nil); err != nil {
+		t.Fatalf("Stop(nil): %v", err)
+	}
+	if err := c.Stop([]*Torrent{}); err != nil {
+		t.Fatalf("Stop([]): %v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("Stop with an empty selector must not call transmission, got %d calls", calls)
+	}
+}
+
+func TestSetLabelsEmptySelectorIsNoop(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"result":"success"}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv)
+	if err := c.SetLabels(nil, []string{"x"}); err != nil {
+		t.Fatalf("SetLabels(nil, ...): %v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("SetLabels with an empty selector must not call transmission, got %d calls", calls)
+	}
+}
+
+func TestSetLocationEmptySelectorIsNoop(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"result":"success"}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv)
+	if err := c.SetLocation(nil, "/data", false); err != nil {
+		t.Fatalf("SetLocation(nil, ...): %v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("SetLocation with an empty selector must not call transmission, got %d calls", calls)
+	}
+}
+
+func TestStopSendsIds(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := make([]byte, 1024)
+		n, _ := r.Body.Read(buf)
+		body = string(buf[:n])
+		w.Write([]byte(`{"result":"success"}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv)
+	if err := c.Stop([]*Torrent{{ID: 7}}); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if body == "" {
+		t.Fatal("expected a request to be sent for a non-empty selector")
+	}
+	if want := `"ids":[7]`; !strings.Contains(body, want) {
+		t.Fatalf("request body %q does not contain %q", body, want)
+	}
+}
+
+func TestStartCtxRespectsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":"success"}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv)
+	if err := c.StartCtx(ctx, []*Torrent{{ID: 1}}); err == nil {
+		t.Fatal("expected an error from an already-canceled context")
+	}
+}
@@ -0,0 +1,35 @@
+package transmission
+
+// torrentGetFields is the set of torrent-get fields requested by
+// GetTorrents; it must stay in sync with the JSON tags on Torrent.
+var torrentGetFields = []string{
+	"id",
+	"hashString",
+	"name",
+	"status",
+	"percentDone",
+	"errorString",
+	"peersConnected",
+}
+
+// Torrent is a single torrent as returned by torrent-get.
+type Torrent struct {
+	ID             int     `json:"id"`
+	HashString     string  `json:"hashString"`
+	Name           string  `json:"name"`
+	Status         int     `json:"status"`
+	PercentDone    float64 `json:"percentDone"`
+	ErrorString    string  `json:"errorString"`
+	PeersConnected int     `json:"peersConnected"`
+
+	// Client is the Client that fetched or added this torrent, set by
+	// Client's GetTorrents/AddTorrent so callers can act on the torrent
+	// directly, e.g. torrent.Client.RemoveTorrents([]*Torrent{torrent}, false).
+	// Torrents returned by QBittorrentClient leave it nil.
+	Client *Client `json:"-"`
+}
+
+// Torrents wraps the torrent-get response's "torrents" array.
+type Torrents struct {
+	Torrents *[]Torrent `json:"torrents"`
+}
@@ -2,19 +2,32 @@ package transmission
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
+	"time"
 )
 
 const (
 	// DefaultAddress default transmission address
 	DefaultAddress = "http://localhost:9091/transmission/rpc"
+	// defaultMaxRetries default number of retries for transient 5xx errors
+	defaultMaxRetries = 3
+	// defaultRetryBackoff base delay between retries, doubled on each attempt
+	defaultRetryBackoff = 200 * time.Millisecond
 )
 
+// Logger is implemented by types that can receive diagnostic logging from
+// the client, such as *log.Logger. It is nil by default, so nothing is
+// logged unless a caller opts in via Config.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
 // Config used to configure transmission client
 type Config struct {
 	// Address defaultt http://localhost:9091/transmission/rpc
@@ -23,6 +36,17 @@ type Config struct {
 	Password string
 	// SkipCheckSSL set to true if you use untrusted certificat default false
 	SkipCheckSSL bool
+	// HTTPClient, when set, is used instead of building a default one from
+	// SkipCheckSSL. Use it to supply a custom transport (proxies, custom TLS
+	// roots, tracing).
+	HTTPClient *http.Client
+	// Logger, when set, receives one line per request/retry. Request
+	// bodies are never logged, since torrent-add arguments can carry
+	// credentials-bearing URLs.
+	Logger Logger
+	// MaxRetries is the number of times a transient 5xx response is
+	// retried, with exponential backoff. Defaults to 3.
+	MaxRetries int
 }
 
 // Client transmission client
@@ -33,6 +57,12 @@ type Client struct {
 	endpoint   string
 }
 
+func (c *Client) logf(format string, v ...interface{}) {
+	if c.conf.Logger != nil {
+		c.conf.Logger.Printf(format, v...)
+	}
+}
+
 type getTorrentArg struct {
 	Fields []string `json:"fields,omitempty"`
 	Ids    []int    `json:"ids,omitempty"`
@@ -45,15 +75,27 @@ type addTorrentArg struct {
 	Filename string `json:"filename,omitempty"`
 	// Metainfo base64-encoded .torrent content
 	Metainfo string `json:"metainfo,omitempty"`
-	// Paused   bool
-	// peer-limit int
-	// BandwidthPriority int
-	// files-wanted
-	// files-unwanted
-	// priority-high
-	// priority-low
-	// priority-normal
-
+	// Webseeds additional HTTP(S) sources transmission attaches alongside
+	// the swarm (BEP-19)
+	Webseeds []string `json:"webseeds,omitempty"`
+	// DownloadDir directory the torrent's data is saved to
+	DownloadDir string `json:"download-dir,omitempty"`
+	// Paused whether to add the torrent in a stopped state
+	Paused *bool `json:"paused,omitempty"`
+	// PeerLimit maximum number of peers
+	PeerLimit int `json:"peer-limit,omitempty"`
+	// BandwidthPriority -1, 0 or 1
+	BandwidthPriority int `json:"bandwidthPriority,omitempty"`
+	// FilesWanted indices of files to download
+	FilesWanted []int `json:"files-wanted,omitempty"`
+	// FilesUnwanted indices of files to skip
+	FilesUnwanted []int `json:"files-unwanted,omitempty"`
+	// PriorityHigh indices of files to download first
+	PriorityHigh []int `json:"priority-high,omitempty"`
+	// PriorityLow indices of files to download last
+	PriorityLow []int `json:"priority-low,omitempty"`
+	// PriorityNormal indices of files with the default priority
+	PriorityNormal []int `json:"priority-normal,omitempty"`
 }
 
 type removeTorrentArg struct {
@@ -73,15 +115,27 @@ type Response struct {
 	Result    string      `json:"result"`
 }
 
-// Do low level function for interact with transmission only take care
-// of authentification and session id
-func (c *Client) Do(req *http.Request, retry bool) (*http.Response, error) {
+// drainAndClose drains and closes resp.Body so the underlying connection
+// can be reused, then discards it. Callers hold onto a *http.Response from
+// an attempt they're about to discard (retrying, or giving up on ctx.Done)
+// and must not leave its body unread/unclosed.
+func drainAndClose(resp *http.Response) {
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// Do low level function for interact with transmission, taking care of
+// authentification, session id renewal on 409, and retrying transient 5xx
+// responses with exponential backoff. retry controls whether a 409 is
+// allowed to renew the session id and resend; callers should pass true.
+func (c *Client) Do(ctx context.Context, req *http.Request, retry bool) (*http.Response, error) {
 	if c.conf.User != "" && c.conf.Password != "" {
 		req.SetBasicAuth(c.conf.User, c.conf.Password)
 	}
 	if c.sessionID != "" {
 		req.Header.Add("X-Transmission-Session-Id", c.sessionID)
 	}
+	req = req.WithContext(ctx)
 
 	//Body copy for replay it if needed
 	b, err := ioutil.ReadAll(req.Body)
@@ -90,23 +144,47 @@ func (c *Client) Do(req *http.Request, retry bool) (*http.Response, error) {
 	}
 	req.Body = ioutil.NopCloser(bytes.NewBuffer(b))
 
-	//Log request for debug
-	log.Print(bytes.NewBuffer(b).String())
+	c.logf("transmission: %s %s", req.Method, req.URL)
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
+	maxRetries := c.conf.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
 	}
-	// error 409
-	if resp.StatusCode == http.StatusConflict && retry {
-		c.sessionID = resp.Header.Get("X-Transmission-Session-Id")
+	backoff := defaultRetryBackoff
+
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
 		req.Body = ioutil.NopCloser(bytes.NewBuffer(b))
-		return c.Do(req, false)
+		resp, err = c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		// error 409: transmission handed us a new session id, resend once
+		if resp.StatusCode == http.StatusConflict && retry {
+			c.sessionID = resp.Header.Get("X-Transmission-Session-Id")
+			req.Header.Set("X-Transmission-Session-Id", c.sessionID)
+			req.Body = ioutil.NopCloser(bytes.NewBuffer(b))
+			drainAndClose(resp)
+			return c.Do(ctx, req, false)
+		}
+
+		if resp.StatusCode < 500 || attempt >= maxRetries {
+			return resp, nil
+		}
+
+		c.logf("transmission: %s returned %s, retrying in %s", req.URL, resp.Status, backoff)
+		drainAndClose(resp)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
 	}
-	return resp, nil
 }
 
-func (c *Client) post(tReq *Request) (*http.Response, error) {
+func (c *Client) post(ctx context.Context, tReq *Request) (*http.Response, error) {
 	data, err := json.Marshal(tReq)
 	if err != nil {
 		return nil, err
@@ -115,11 +193,11 @@ func (c *Client) post(tReq *Request) (*http.Response, error) {
 	if err != nil {
 		return nil, err
 	}
-	return c.Do(req, true)
+	return c.Do(ctx, req, true)
 }
 
-func (c *Client) request(tReq *Request, tResp *Response) error {
-	resp, err := c.post(tReq)
+func (c *Client) request(ctx context.Context, tReq *Request, tResp *Response) error {
+	resp, err := c.post(ctx, tReq)
 	if err != nil {
 		return err
 	}
@@ -140,6 +218,11 @@ func (c *Client) request(tReq *Request, tResp *Response) error {
 
 // GetTorrents return list of torrent
 func (c *Client) GetTorrents() (*[]Torrent, error) {
+	return c.GetTorrentsCtx(context.Background())
+}
+
+// GetTorrentsCtx is GetTorrents with a caller-supplied context.
+func (c *Client) GetTorrentsCtx(ctx context.Context) (*[]Torrent, error) {
 	tReq := &Request{
 		Arguments: getTorrentArg{
 			Fields: torrentGetFields,
@@ -149,7 +232,7 @@ func (c *Client) GetTorrents() (*[]Torrent, error) {
 
 	r := &Response{Arguments: &Torrents{}}
 
-	err := c.request(tReq, r)
+	err := c.request(ctx, tReq, r)
 	if err != nil {
 		return nil, err
 	}
@@ -165,6 +248,11 @@ func (c *Client) GetTorrents() (*[]Torrent, error) {
 // filename is an url or a path
 // metadata is base64 encoded content of torrent file
 func (c *Client) AddTorrent(filename, metadata string) (*Torrent, error) {
+	return c.AddTorrentCtx(context.Background(), filename, metadata)
+}
+
+// AddTorrentCtx is AddTorrent with a caller-supplied context.
+func (c *Client) AddTorrentCtx(ctx context.Context, filename, metadata string) (*Torrent, error) {
 	tReq := &Request{
 		Arguments: addTorrentArg{
 			Filename: filename,
@@ -176,7 +264,7 @@ func (c *Client) AddTorrent(filename, metadata string) (*Torrent, error) {
 		Torrent *Torrent `json:"torrent-added"`
 	}
 	r := &Response{Arguments: &added{}}
-	err := c.request(tReq, r)
+	err := c.request(ctx, tReq, r)
 	if err != nil {
 		return nil, err
 	}
@@ -187,6 +275,11 @@ func (c *Client) AddTorrent(filename, metadata string) (*Torrent, error) {
 
 // RemoveTorrents remove torrents
 func (c *Client) RemoveTorrents(torrents []*Torrent, removeData bool) error {
+	return c.RemoveTorrentsCtx(context.Background(), torrents, removeData)
+}
+
+// RemoveTorrentsCtx is RemoveTorrents with a caller-supplied context.
+func (c *Client) RemoveTorrentsCtx(ctx context.Context, torrents []*Torrent, removeData bool) error {
 	ids := make([]int, len(torrents))
 	for i := range torrents {
 		ids[i] = torrents[i].ID
@@ -199,24 +292,28 @@ func (c *Client) RemoveTorrents(torrents []*Torrent, removeData bool) error {
 		Method: "torrent-remove",
 	}
 	r := &Response{}
-	err := c.request(tReq, r)
-	if err != nil {
-		return err
-	}
-	return nil
+	return c.request(ctx, tReq, r)
 }
 
 // New create a new transmission client
 func New(conf Config) (*Client, error) {
-	httpClient := &http.Client{}
-	if conf.SkipCheckSSL {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		httpClient = &http.Client{Transport: tr}
+	httpClient := conf.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient(conf.SkipCheckSSL)
 	}
 	if conf.Address == "" {
 		conf.Address = DefaultAddress
 	}
 	return &Client{conf: &conf, httpClient: httpClient, endpoint: conf.Address}, nil
 }
+
+func defaultHTTPClient(skipCheckSSL bool) *http.Client {
+	if !skipCheckSSL {
+		return &http.Client{}
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+}
@@ -0,0 +1,148 @@
+package transmission
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestDoResendsBodyAfter409 is a regression test: the first request gets a
+// 409 with a fresh session id, and the client must resend the exact same
+// body on retry, not an empty one drained by the failed attempt.
+func TestDoResendsBodyAfter409(t *testing.T) {
+	const body = `{"method":"torrent-get","arguments":{}}`
+	var calls int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		got, _ := ioutil.ReadAll(r.Body)
+		if calls == 1 {
+			w.Header().Set("X-Transmission-Session-Id", "abc123")
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		if string(got) != body {
+			t.Errorf("retry body = %q, want %q", got, body)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"result":"success"}`))
+	}))
+	defer srv.Close()
+
+	c := &Client{httpClient: srv.Client(), conf: &Config{}, endpoint: srv.URL}
+	req, err := http.NewRequest("POST", srv.URL, ioutil.NopCloser(strings.NewReader(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.Do(context.Background(), req, true)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("final status = %d, want 200", resp.StatusCode)
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+}
+
+// TestDoRetriesTransient5xx checks that a transient 5xx is retried and a
+// subsequent success is returned without exhausting MaxRetries.
+func TestDoRetriesTransient5xx(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := &Client{httpClient: srv.Client(), conf: &Config{MaxRetries: 2}, endpoint: srv.URL}
+	req, err := http.NewRequest("POST", srv.URL, ioutil.NopCloser(strings.NewReader("{}")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.Do(context.Background(), req, true)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("final status = %d, want 200", resp.StatusCode)
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+}
+
+// trackedBody is an io.ReadCloser that records whether it was closed, used
+// to catch a retried attempt's response body being left open.
+type trackedBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+// countingTransport serves canned responses with bodies wrapped in
+// trackedBody, so a test can assert every discarded attempt got its body
+// closed.
+type countingTransport struct {
+	statuses []int
+	bodies   []*trackedBody
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	i := len(t.bodies)
+	body := &trackedBody{Reader: strings.NewReader("{}")}
+	t.bodies = append(t.bodies, body)
+	return &http.Response{
+		StatusCode: t.statuses[i],
+		Body:       body,
+		Header:     make(http.Header),
+	}, nil
+}
+
+// TestDoClosesDiscardedAttemptBodies is a regression test: every response
+// body belonging to an attempt the retry loop throws away (a retried 5xx)
+// must be closed, not leaked along with its connection.
+func TestDoClosesDiscardedAttemptBodies(t *testing.T) {
+	transport := &countingTransport{statuses: []int{503, 503, 200}}
+	c := &Client{
+		httpClient: &http.Client{Transport: transport},
+		conf:       &Config{MaxRetries: 2},
+		endpoint:   "http://example.invalid",
+	}
+	req, err := http.NewRequest("POST", "http://example.invalid", ioutil.NopCloser(strings.NewReader("{}")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.Do(context.Background(), req, true)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if len(transport.bodies) != 3 {
+		t.Fatalf("got %d attempts, want 3", len(transport.bodies))
+	}
+	for i, b := range transport.bodies[:2] {
+		if !b.closed {
+			t.Errorf("attempt %d: body not closed before retrying", i)
+		}
+	}
+}
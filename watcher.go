@@ -0,0 +1,144 @@
+package transmission
+
+import (
+	"context"
+	"time"
+)
+
+// statusSeed is the torrent-get "status" value for a torrent that has
+// finished downloading and is seeding, per the Transmission RPC spec.
+const statusSeed = 6
+
+// TorrentSnapshot is the subset of a torrent's state a Watcher diffs
+// between polls.
+type TorrentSnapshot struct {
+	ID             int
+	Status         int
+	PercentDone    float64
+	ErrorString    string
+	PeersConnected int
+}
+
+func snapshotOf(t *Torrent) TorrentSnapshot {
+	return TorrentSnapshot{
+		ID:             t.ID,
+		Status:         t.Status,
+		PercentDone:    t.PercentDone,
+		ErrorString:    t.ErrorString,
+		PeersConnected: t.PeersConnected,
+	}
+}
+
+// WatchHandler receives the events a Watcher fires while diffing snapshots
+// across polls. Any callback left nil is simply not invoked.
+type WatchHandler struct {
+	// Added fires the first time a torrent is seen.
+	Added func(TorrentSnapshot)
+	// Removed fires once a previously seen torrent no longer appears.
+	Removed func(TorrentSnapshot)
+	// Completed fires when a torrent's status transitions to seeding.
+	Completed func(TorrentSnapshot)
+	// Errored fires when a torrent first reports a non-empty ErrorString.
+	Errored func(TorrentSnapshot)
+	// ProgressChanged fires when PercentDone changes, with the delta.
+	ProgressChanged func(t TorrentSnapshot, delta float64)
+	// StatusChanged fires on any status transition.
+	StatusChanged func(t TorrentSnapshot, oldStatus, newStatus int)
+	// PeersChanged fires when the connected peer count changes.
+	PeersChanged func(t TorrentSnapshot, oldPeers, newPeers int)
+}
+
+// Watcher polls GetTorrentsCtx on an interval and diffs the result against
+// the previous poll, so callers don't each reimplement the polling/diff
+// loop to notice additions, removals, and status transitions. It works
+// against any TorrentClient, not just Transmission.
+type Watcher struct {
+	client   TorrentClient
+	interval time.Duration
+	handler  WatchHandler
+
+	snapshots map[int]TorrentSnapshot
+}
+
+// Watch starts a Watcher polling client every interval in the background;
+// it fires handler callbacks until ctx is canceled.
+func Watch(ctx context.Context, client TorrentClient, interval time.Duration, handler WatchHandler) *Watcher {
+	w := &Watcher{
+		client:    client,
+		interval:  interval,
+		handler:   handler,
+		snapshots: map[int]TorrentSnapshot{},
+	}
+	go w.run(ctx)
+	return w
+}
+
+func (w *Watcher) run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll(ctx)
+		}
+	}
+}
+
+func (w *Watcher) poll(ctx context.Context) {
+	torrents, err := w.client.GetTorrentsCtx(ctx)
+	if err != nil {
+		return
+	}
+
+	seen := make(map[int]bool, len(*torrents))
+	for i := range *torrents {
+		next := snapshotOf(&(*torrents)[i])
+		seen[next.ID] = true
+
+		prev, existed := w.snapshots[next.ID]
+		w.snapshots[next.ID] = next
+
+		if !existed {
+			w.fire(w.handler.Added, next)
+			continue
+		}
+		w.diff(prev, next)
+	}
+
+	for id, prev := range w.snapshots {
+		if !seen[id] {
+			delete(w.snapshots, id)
+			w.fire(w.handler.Removed, prev)
+		}
+	}
+}
+
+// diff compares one torrent's previous and current snapshot and fires every
+// event that transition implies, coalescing within this single tick.
+func (w *Watcher) diff(prev, next TorrentSnapshot) {
+	if prev.Status != next.Status {
+		if w.handler.StatusChanged != nil {
+			w.handler.StatusChanged(next, prev.Status, next.Status)
+		}
+		if next.Status == statusSeed && prev.Status != statusSeed {
+			w.fire(w.handler.Completed, next)
+		}
+	}
+	if next.ErrorString != "" && prev.ErrorString == "" {
+		w.fire(w.handler.Errored, next)
+	}
+	if next.PercentDone != prev.PercentDone && w.handler.ProgressChanged != nil {
+		w.handler.ProgressChanged(next, next.PercentDone-prev.PercentDone)
+	}
+	if next.PeersConnected != prev.PeersConnected && w.handler.PeersChanged != nil {
+		w.handler.PeersChanged(next, prev.PeersConnected, next.PeersConnected)
+	}
+}
+
+func (w *Watcher) fire(f func(TorrentSnapshot), snap TorrentSnapshot) {
+	if f != nil {
+		f(snap)
+	}
+}
@@ -0,0 +1,135 @@
+package transmission
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWatcherDiffCompleted(t *testing.T) {
+	var completed []TorrentSnapshot
+	w := &Watcher{handler: WatchHandler{
+		Completed: func(s TorrentSnapshot) { completed = append(completed, s) },
+	}}
+
+	prev := TorrentSnapshot{ID: 1, Status: 4}
+	next := TorrentSnapshot{ID: 1, Status: statusSeed}
+	w.diff(prev, next)
+
+	if len(completed) != 1 || completed[0].ID != 1 {
+		t.Fatalf("Completed fired %v, want one event for id 1", completed)
+	}
+}
+
+func TestWatcherDiffStatusChanged(t *testing.T) {
+	var got []int
+	w := &Watcher{handler: WatchHandler{
+		StatusChanged: func(_ TorrentSnapshot, oldStatus, newStatus int) {
+			got = append(got, oldStatus, newStatus)
+		},
+	}}
+
+	w.diff(TorrentSnapshot{ID: 1, Status: 0}, TorrentSnapshot{ID: 1, Status: 4})
+
+	if len(got) != 2 || got[0] != 0 || got[1] != 4 {
+		t.Fatalf("StatusChanged args = %v, want [0 4]", got)
+	}
+}
+
+func TestWatcherDiffNoStatusChange(t *testing.T) {
+	fired := false
+	w := &Watcher{handler: WatchHandler{
+		StatusChanged: func(_ TorrentSnapshot, _, _ int) { fired = true },
+		Completed:     func(_ TorrentSnapshot) { fired = true },
+	}}
+
+	w.diff(TorrentSnapshot{ID: 1, Status: 4}, TorrentSnapshot{ID: 1, Status: 4})
+
+	if fired {
+		t.Fatal("no callback should fire when status is unchanged")
+	}
+}
+
+func TestWatcherDiffErrored(t *testing.T) {
+	var errored []TorrentSnapshot
+	w := &Watcher{handler: WatchHandler{
+		Errored: func(s TorrentSnapshot) { errored = append(errored, s) },
+	}}
+
+	// only the transition into an error should fire, not every poll
+	// while the torrent stays errored.
+	w.diff(TorrentSnapshot{ID: 1}, TorrentSnapshot{ID: 1, ErrorString: "disk full"})
+	w.diff(TorrentSnapshot{ID: 1, ErrorString: "disk full"}, TorrentSnapshot{ID: 1, ErrorString: "disk full"})
+
+	if len(errored) != 1 {
+		t.Fatalf("Errored fired %d times, want 1", len(errored))
+	}
+}
+
+func TestWatcherDiffProgressAndPeersChanged(t *testing.T) {
+	var delta float64
+	var oldPeers, newPeers int
+	w := &Watcher{handler: WatchHandler{
+		ProgressChanged: func(_ TorrentSnapshot, d float64) { delta = d },
+		PeersChanged: func(_ TorrentSnapshot, old, new int) {
+			oldPeers, newPeers = old, new
+		},
+	}}
+
+	w.diff(
+		TorrentSnapshot{ID: 1, PercentDone: 0.5, PeersConnected: 3},
+		TorrentSnapshot{ID: 1, PercentDone: 0.75, PeersConnected: 5},
+	)
+
+	if delta != 0.25 {
+		t.Errorf("ProgressChanged delta = %v, want 0.25", delta)
+	}
+	if oldPeers != 3 || newPeers != 5 {
+		t.Errorf("PeersChanged = (%d, %d), want (3, 5)", oldPeers, newPeers)
+	}
+}
+
+// fakeTorrentClient is a stub TorrentClient whose GetTorrentsCtx returns a
+// fixed list, used to exercise Watcher.poll without a live backend.
+type fakeTorrentClient struct {
+	torrents []Torrent
+}
+
+func (f *fakeTorrentClient) GetTorrents() (*[]Torrent, error) { return &f.torrents, nil }
+func (f *fakeTorrentClient) GetTorrentsCtx(context.Context) (*[]Torrent, error) {
+	return &f.torrents, nil
+}
+func (f *fakeTorrentClient) AddTorrent(string, string) (*Torrent, error) { return nil, nil }
+func (f *fakeTorrentClient) RemoveTorrents([]*Torrent, bool) error       { return nil }
+func (f *fakeTorrentClient) Start([]*Torrent) error                      { return nil }
+func (f *fakeTorrentClient) StartNow([]*Torrent) error                   { return nil }
+func (f *fakeTorrentClient) Stop([]*Torrent) error                       { return nil }
+func (f *fakeTorrentClient) Verify([]*Torrent) error                     { return nil }
+func (f *fakeTorrentClient) Reannounce([]*Torrent) error                 { return nil }
+func (f *fakeTorrentClient) SetLocation([]*Torrent, string, bool) error  { return nil }
+
+var _ TorrentClient = (*fakeTorrentClient)(nil)
+
+func TestWatcherPollAddedAndRemoved(t *testing.T) {
+	var added, removed []int
+	fake := &fakeTorrentClient{torrents: []Torrent{{ID: 1}}}
+	w := &Watcher{
+		client: fake,
+		handler: WatchHandler{
+			Added:   func(s TorrentSnapshot) { added = append(added, s.ID) },
+			Removed: func(s TorrentSnapshot) { removed = append(removed, s.ID) },
+		},
+		snapshots: map[int]TorrentSnapshot{2: {ID: 2}},
+	}
+
+	w.poll(context.Background())
+
+	if len(added) != 1 || added[0] != 1 {
+		t.Errorf("Added = %v, want [1]", added)
+	}
+	if len(removed) != 1 || removed[0] != 2 {
+		t.Errorf("Removed = %v, want [2]", removed)
+	}
+	if _, stillTracked := w.snapshots[2]; stillTracked {
+		t.Error("removed torrent should no longer be tracked")
+	}
+}
@@ -0,0 +1,98 @@
+package transmission
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// AddTorrentOptions are the optional torrent-add arguments beyond filename
+// and metainfo: webseeds, placement, and initial file selection/priority.
+type AddTorrentOptions struct {
+	// Webseeds are additional HTTP(S) URLs transmission attaches as extra
+	// sources alongside the swarm (BEP-19).
+	Webseeds []string
+	// DownloadDir is the directory the torrent's data is saved to.
+	DownloadDir string
+	// Paused adds the torrent in a stopped state when true.
+	Paused bool
+	// PeerLimit caps the number of peers, 0 means unset.
+	PeerLimit int
+	// BandwidthPriority is -1, 0 or 1.
+	BandwidthPriority int
+	// FilesWanted and FilesUnwanted are file indices, applied once the
+	// torrent's metadata is known.
+	FilesWanted   []int
+	FilesUnwanted []int
+	// PriorityHigh, PriorityLow and PriorityNormal are file indices.
+	PriorityHigh   []int
+	PriorityLow    []int
+	PriorityNormal []int
+}
+
+// ParseWebseedList splits a comma-separated list of webseed URLs, trimming
+// whitespace and dropping empty entries.
+func ParseWebseedList(list string) []string {
+	var urls []string
+	for _, u := range strings.Split(list, ",") {
+		u = strings.TrimSpace(u)
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// AddTorrentWithOptions adds a torrent from a filename/URL or base64-encoded
+// metainfo, same as AddTorrent, applying the given options (webseeds,
+// download directory, initial file selection/priority) in the same call.
+func (c *Client) AddTorrentWithOptions(filename, metadata string, opts AddTorrentOptions) (*Torrent, error) {
+	return c.AddTorrentWithOptionsCtx(context.Background(), filename, metadata, opts)
+}
+
+// AddTorrentWithOptionsCtx is AddTorrentWithOptions with a caller-supplied context.
+func (c *Client) AddTorrentWithOptionsCtx(ctx context.Context, filename, metadata string, opts AddTorrentOptions) (*Torrent, error) {
+	arg := addTorrentArg{
+		Filename:          filename,
+		Metainfo:          metadata,
+		Webseeds:          opts.Webseeds,
+		DownloadDir:       opts.DownloadDir,
+		PeerLimit:         opts.PeerLimit,
+		BandwidthPriority: opts.BandwidthPriority,
+		FilesWanted:       opts.FilesWanted,
+		FilesUnwanted:     opts.FilesUnwanted,
+		PriorityHigh:      opts.PriorityHigh,
+		PriorityLow:       opts.PriorityLow,
+		PriorityNormal:    opts.PriorityNormal,
+	}
+	if opts.Paused {
+		arg.Paused = &opts.Paused
+	}
+
+	tReq := &Request{
+		Arguments: arg,
+		Method:    "torrent-add",
+	}
+	// Transmission reports an add of an already-known torrent under
+	// "torrent-duplicate" instead of "torrent-added", so both must be
+	// decoded or a duplicate add panics on a nil Torrent.
+	type added struct {
+		Added     *Torrent `json:"torrent-added"`
+		Duplicate *Torrent `json:"torrent-duplicate"`
+	}
+	r := &Response{Arguments: &added{}}
+	err := c.request(ctx, tReq, r)
+	if err != nil {
+		return nil, err
+	}
+	a := r.Arguments.(*added)
+	t := a.Added
+	if t == nil {
+		t = a.Duplicate
+	}
+	if t == nil {
+		return nil, fmt.Errorf("transmission: torrent-add response had neither torrent-added nor torrent-duplicate")
+	}
+	t.Client = c
+	return t, nil
+}
@@ -0,0 +1,34 @@
+package transmission
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestAddTorrentWithOptionsHandlesDuplicate is a regression test: a
+// torrent-add response that Transmission reports under "torrent-duplicate"
+// (an already-known torrent, not "torrent-added") must still return a
+// usable Torrent instead of panicking on a nil pointer.
+func TestAddTorrentWithOptionsHandlesDuplicate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":"success","arguments":{"torrent-duplicate":{"id":7,"hashString":"abc"}}}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv)
+	got, err := c.AddTorrentWithOptionsCtx(context.Background(), "magnet:?xt=urn:btih:abc", "", AddTorrentOptions{})
+	if err != nil {
+		t.Fatalf("AddTorrentWithOptionsCtx: %v", err)
+	}
+	if got == nil {
+		t.Fatal("got a nil Torrent for a torrent-duplicate response")
+	}
+	if got.ID != 7 || got.HashString != "abc" {
+		t.Errorf("got = %+v, want id 7 hash abc", got)
+	}
+	if got.Client != c {
+		t.Error("Client was not set on the returned Torrent")
+	}
+}